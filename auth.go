@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role scopes what a Client is permitted to do once connected to a Hub.
+type Role string
+
+const (
+	RoleInterviewer Role = "interviewer"
+	RoleCandidate   Role = "candidate"
+)
+
+// tokenTTL bounds how long a session token is valid for after /create
+// issues it.
+const tokenTTL = 4 * time.Hour
+
+// tokenHeader is the fixed JOSE-style header for every token this server
+// issues; it's included (and its signature verified) purely to keep the
+// token shape recognizable as a JWT, since nothing here needs more than
+// one signing algorithm.
+const tokenHeader = `{"alg":"HS256","typ":"JWT"}`
+
+// tokenClaims is the payload embedded in a session token.
+type tokenClaims struct {
+	SessionID string `json:"sessionId"`
+	Role      Role   `json:"role"`
+	Exp       int64  `json:"exp"`
+}
+
+var (
+	secretOnce sync.Once
+	secret     []byte
+)
+
+// signingSecret returns the HMAC key used to sign and verify session
+// tokens, loaded once from the SESSION_TOKEN_SECRET environment variable.
+// If it's unset, an ephemeral per-process key is generated instead so
+// local development doesn't require configuring one; tokens then stop
+// validating across a restart.
+func signingSecret() []byte {
+	secretOnce.Do(func() {
+		if s := os.Getenv("SESSION_TOKEN_SECRET"); s != "" {
+			secret = []byte(s)
+			return
+		}
+		log.Println("SESSION_TOKEN_SECRET not set; using an ephemeral per-process secret (session tokens won't survive a restart)")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("generate ephemeral session token secret: %v", err)
+		}
+	})
+	return secret
+}
+
+// issueToken returns a signed, time-boxed token granting role access to
+// sessionID.
+func issueToken(sessionID string, role Role) (string, error) {
+	claims := tokenClaims{SessionID: sessionID, Role: role, Exp: time.Now().Add(tokenTTL).Unix()}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal token claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(tokenHeader)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + sign(signingInput), nil
+}
+
+// parseToken verifies token's signature and expiry and returns its claims.
+func parseToken(token string) (tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, fmt.Errorf("malformed token")
+	}
+	signingInput := parts[0] + "." + parts[1]
+
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(signingInput))) {
+		return tokenClaims{}, fmt.Errorf("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return tokenClaims{}, fmt.Errorf("decode token payload: %w", err)
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return tokenClaims{}, fmt.Errorf("decode token claims: %w", err)
+	}
+	if time.Now().Unix() > claims.Exp {
+		return tokenClaims{}, fmt.Errorf("token expired")
+	}
+	return claims, nil
+}
+
+// sign returns the base64url-encoded HMAC-SHA256 of signingInput.
+func sign(signingInput string) string {
+	mac := hmac.New(sha256.New, signingSecret())
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
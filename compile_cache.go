@@ -0,0 +1,128 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// compileCacheMaxBytes bounds the total on-disk size of cached artifacts;
+// the least recently used entry is evicted once a new one would exceed it.
+const compileCacheMaxBytes = 256 << 20 // 256MB
+
+// cacheKey returns the content address for a (language, code) pair.
+func cacheKey(language, code string) string {
+	sum := sha256.Sum256([]byte(language + "\x00" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheEntry is one cached artifact's in-memory metadata; the artifact
+// bytes themselves live on disk at ResultCache.dir/key.
+type cacheEntry struct {
+	key         string
+	contentType string
+	size        int64
+	element     *list.Element
+}
+
+// ResultCache is a content-addressed, on-disk cache of compiled artifacts,
+// bounded to maxBytes by an in-memory LRU index.
+type ResultCache struct {
+	dir      string
+	maxBytes int64
+
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry
+	order      *list.List // front = most recently used
+	totalBytes int64
+}
+
+// newResultCache opens (creating if necessary) an on-disk cache rooted at
+// dir, bounded to maxBytes. dir is resolved to an absolute path first, so
+// cached artifacts stay reachable regardless of the process's working
+// directory.
+func newResultCache(dir string, maxBytes int64) (*ResultCache, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	return &ResultCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*cacheEntry),
+		order:    list.New(),
+	}, nil
+}
+
+// get returns the artifact stored under key, if present, marking it most
+// recently used.
+func (c *ResultCache) get(key string) (artifact []byte, contentType string, ok bool) {
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	if found {
+		c.order.MoveToFront(entry.element)
+	}
+	c.mu.Unlock()
+	if !found {
+		return nil, "", false
+	}
+
+	artifact, err := os.ReadFile(filepath.Join(c.dir, key))
+	if err != nil {
+		return nil, "", false
+	}
+	return artifact, entry.contentType, true
+}
+
+// put stores artifact under key, evicting least-recently-used entries
+// until the cache fits within maxBytes.
+func (c *ResultCache) put(key, contentType string, artifact []byte) {
+	if err := os.WriteFile(filepath.Join(c.dir, key), artifact, 0644); err != nil {
+		log.Printf("compile cache: write %s: %v", key, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.entries[key]; ok {
+		c.order.MoveToFront(existing.element)
+		c.totalBytes += int64(len(artifact)) - existing.size
+		existing.size = int64(len(artifact))
+		existing.contentType = contentType
+		c.evictLocked()
+		return
+	}
+
+	entry := &cacheEntry{key: key, contentType: contentType, size: int64(len(artifact))}
+	entry.element = c.order.PushFront(entry)
+	c.entries[key] = entry
+	c.totalBytes += entry.size
+	c.evictLocked()
+}
+
+// evictLocked removes least-recently-used entries until totalBytes fits
+// within maxBytes. c.mu must be held.
+func (c *ResultCache) evictLocked() {
+	for c.totalBytes > c.maxBytes {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.totalBytes -= entry.size
+		if err := os.Remove(filepath.Join(c.dir, entry.key)); err != nil && !os.IsNotExist(err) {
+			log.Printf("compile cache: evict %s: %v", entry.key, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestResultCacheGetPutRoundTrip(t *testing.T) {
+	cache, err := newResultCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("newResultCache: %v", err)
+	}
+
+	if _, _, ok := cache.get("missing"); ok {
+		t.Fatalf("get() on empty cache returned ok=true")
+	}
+
+	cache.put("key-1", "application/wasm", []byte("artifact"))
+
+	artifact, contentType, ok := cache.get("key-1")
+	if !ok {
+		t.Fatalf("get() after put returned ok=false")
+	}
+	if string(artifact) != "artifact" || contentType != "application/wasm" {
+		t.Errorf("get() = %q, %q; want %q, %q", artifact, contentType, "artifact", "application/wasm")
+	}
+}
+
+func TestResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := newResultCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("newResultCache: %v", err)
+	}
+
+	cache.put("a", "text/plain", []byte("0123456789")) // fills the 10-byte cap
+	cache.put("b", "text/plain", []byte("0123456789")) // evicts "a"
+
+	if _, _, ok := cache.get("a"); ok {
+		t.Errorf("get(%q) returned ok=true, want evicted", "a")
+	}
+	if _, _, ok := cache.get("b"); !ok {
+		t.Errorf("get(%q) returned ok=false, want present", "b")
+	}
+}
+
+func TestCacheKeyDistinguishesLanguageAndCode(t *testing.T) {
+	if cacheKey("go", "a") == cacheKey("go", "b") {
+		t.Errorf("cacheKey() collided for different code")
+	}
+	if cacheKey("go", "a") == cacheKey("python", "a") {
+		t.Errorf("cacheKey() collided for different languages")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// compilerPoolSize bounds how many Go builds can run concurrently; requests
+// past this limit queue for a free worker instead of spawning their own.
+const compilerPoolSize = 4
+
+// compilerCheckoutTimeout bounds how long a request waits for a free worker
+// before /compile gives up and reports the pool as saturated.
+const compilerCheckoutTimeout = 5 * time.Second
+
+// ErrPoolSaturated is returned by CompilerPool.checkout when no worker came
+// free before compilerCheckoutTimeout elapsed.
+var ErrPoolSaturated = errors.New("compiler pool saturated: timed out waiting for a free worker")
+
+var compilerActiveWorkers = expvar.NewInt("compiler_active_workers")
+
+// compilerWorker is a pre-created Go build working directory. Workers are
+// reused rather than recreated per build: that, combined with the shared
+// GOCACHE/GOMODCACHE every worker builds against, is what lets the Go
+// toolchain skip recompiling the standard library on every submission.
+type compilerWorker struct {
+	dir string
+}
+
+// CompilerPool hands out a bounded number of warm Go build workers,
+// checked out and returned over a buffered channel so callers past the
+// concurrency limit wait rather than spawning unbounded builds.
+type CompilerPool struct {
+	workers         chan *compilerWorker
+	checkoutTimeout time.Duration
+	goCache         string
+	goModCache      string
+}
+
+// newCompilerPool creates size worker directories under baseDir, all
+// sharing a single GOCACHE/GOMODCACHE pair under baseDir so the build cache
+// stays warm across requests instead of starting cold per worker. baseDir
+// is resolved to an absolute path first, since the Go toolchain rejects a
+// relative GOCACHE/GOMODCACHE outright.
+func newCompilerPool(size int, baseDir string, checkoutTimeout time.Duration) (*CompilerPool, error) {
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", baseDir, err)
+	}
+
+	goCache := filepath.Join(baseDir, "gocache")
+	goModCache := filepath.Join(baseDir, "gomodcache")
+	for _, dir := range []string{goCache, goModCache} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create %s: %w", dir, err)
+		}
+	}
+
+	pool := &CompilerPool{
+		workers:         make(chan *compilerWorker, size),
+		checkoutTimeout: checkoutTimeout,
+		goCache:         goCache,
+		goModCache:      goModCache,
+	}
+	for i := 0; i < size; i++ {
+		dir := filepath.Join(baseDir, fmt.Sprintf("worker-%d", i))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create %s: %w", dir, err)
+		}
+		pool.workers <- &compilerWorker{dir: dir}
+	}
+	return pool, nil
+}
+
+// checkout waits for a free worker, returning ErrPoolSaturated if
+// checkoutTimeout elapses first or ctx's own error if ctx is done first.
+func (p *CompilerPool) checkout(ctx context.Context) (*compilerWorker, error) {
+	select {
+	case worker := <-p.workers:
+		compilerActiveWorkers.Add(1)
+		return worker, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(p.checkoutTimeout):
+		compilerQueueRejections.Add(1)
+		return nil, ErrPoolSaturated
+	}
+}
+
+// checkin returns worker to the pool for reuse by the next build.
+func (p *CompilerPool) checkin(worker *compilerWorker) {
+	compilerActiveWorkers.Add(-1)
+	p.workers <- worker
+}
+
+// buildEnv returns the environment a worker's "go build" invocation should
+// run with: js/wasm target, pointed at the pool's shared build caches.
+func (p *CompilerPool) buildEnv() []string {
+	return append(os.Environ(),
+		"GOOS=js",
+		"GOARCH=wasm",
+		"GOCACHE="+p.goCache,
+		"GOMODCACHE="+p.goModCache,
+	)
+}
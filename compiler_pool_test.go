@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCompilerPoolCheckoutReturnsWorkerToPool(t *testing.T) {
+	pool, err := newCompilerPool(1, t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("newCompilerPool: %v", err)
+	}
+
+	worker, err := pool.checkout(context.Background())
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	pool.checkin(worker)
+
+	if _, err := pool.checkout(context.Background()); err != nil {
+		t.Fatalf("checkout after checkin: %v", err)
+	}
+}
+
+func TestCompilerPoolCheckoutTimesOutWhenSaturated(t *testing.T) {
+	pool, err := newCompilerPool(1, t.TempDir(), 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("newCompilerPool: %v", err)
+	}
+
+	if _, err := pool.checkout(context.Background()); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	if _, err := pool.checkout(context.Background()); !errors.Is(err, ErrPoolSaturated) {
+		t.Errorf("checkout() with no free worker = %v, want %v", err, ErrPoolSaturated)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// OpID identifies a single character insertion — or, for a delete op, the
+// character being removed — by the site that authored it and that site's
+// Lamport clock value at the time the server integrated it.
+type OpID struct {
+	SiteID string `json:"siteId"`
+	Clock  uint64 `json:"clock"`
+}
+
+func (id OpID) isZero() bool {
+	return id.SiteID == "" && id.Clock == 0
+}
+
+const (
+	actionInsert = "insert"
+	actionDelete = "delete"
+)
+
+// Op is a single CRDT mutation. Clients submit it with a tentative OpID;
+// the server overwrites Clock with the document's Lamport clock before
+// integrating and rebroadcasting it, so every replica agrees on order. For
+// an insert, Parent is the OpID the new character follows (zero for the
+// start of the document); for a delete, Parent is the OpID of the
+// character being removed.
+type Op struct {
+	Type   string `json:"type"`
+	OpID   OpID   `json:"opId"`
+	Parent OpID   `json:"parent"`
+	Action string `json:"action"`
+	Pos    int    `json:"pos,omitempty"`
+	Char   string `json:"char,omitempty"`
+}
+
+// item is one character (or tombstone, once deleted) in the document's RGA
+// list.
+type item struct {
+	id        OpID
+	parent    OpID
+	char      string
+	tombstone bool
+	next      *item
+}
+
+// snapshotItem is the wire form of an item, sent to clients joining a
+// session mid-flight so they can rebuild document state without replaying
+// every historical op.
+type snapshotItem struct {
+	ID        OpID   `json:"id"`
+	Parent    OpID   `json:"parent"`
+	Char      string `json:"char"`
+	Tombstone bool   `json:"tombstone"`
+}
+
+// Document is a per-session Replicated Growable Array (RGA): a sequence
+// CRDT that lets concurrent inserts/deletes from multiple clients converge
+// on the same text without a central lock on the editing surface itself.
+// Items form a singly linked list rooted at head; concurrent children of
+// the same parent are ordered by (clock desc, siteID desc) so every
+// replica resolves ties the same way regardless of arrival order.
+type Document struct {
+	mu      sync.Mutex
+	head    *item
+	index   map[OpID]*item
+	clock   uint64
+	version map[string]uint64
+}
+
+func newDocument() *Document {
+	return &Document{
+		head:    &item{},
+		index:   make(map[OpID]*item),
+		version: make(map[string]uint64),
+	}
+}
+
+// Integrate assigns op the document's next Lamport clock value, applies it
+// (inserting a new character or tombstoning an existing one), and returns
+// the finalized op so the caller can broadcast exactly what was integrated.
+func (d *Document) Integrate(op Op) Op {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.clock++
+	op.OpID.Clock = d.clock
+	if op.OpID.Clock > d.version[op.OpID.SiteID] {
+		d.version[op.OpID.SiteID] = op.OpID.Clock
+	}
+
+	switch op.Action {
+	case actionDelete:
+		if target, ok := d.index[op.Parent]; ok {
+			target.tombstone = true
+		}
+	default:
+		newItem := &item{id: op.OpID, parent: op.Parent, char: op.Char}
+		d.index[op.OpID] = newItem
+		d.insertAfterParent(newItem)
+	}
+	return op
+}
+
+// insertAfterParent splices newItem into the list immediately after its
+// parent, skipping over any existing siblings (items sharing the same
+// parent) that outrank it so concurrent inserts at the same position
+// converge on a single order across replicas.
+func (d *Document) insertAfterParent(newItem *item) {
+	parent := d.head
+	if !newItem.parent.isZero() {
+		if p, ok := d.index[newItem.parent]; ok {
+			parent = p
+		}
+	}
+
+	prev := parent
+	cur := parent.next
+	for cur != nil && cur.parent == newItem.parent && outranks(cur.id, newItem.id) {
+		prev = cur
+		cur = cur.next
+	}
+	newItem.next = cur
+	prev.next = newItem
+}
+
+// outranks reports whether a should sort before b among siblings: higher
+// clock wins, ties broken by siteID so the order is deterministic.
+func outranks(a, b OpID) bool {
+	if a.Clock != b.Clock {
+		return a.Clock > b.Clock
+	}
+	return a.SiteID > b.SiteID
+}
+
+// Text walks the list in order, skipping tombstones, and returns the
+// document's current contents.
+func (d *Document) Text() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	for cur := d.head.next; cur != nil; cur = cur.next {
+		if !cur.tombstone {
+			b.WriteString(cur.char)
+		}
+	}
+	return b.String()
+}
+
+// Snapshot returns every item (including tombstones, so deletes stay
+// consistent) in document order along with the current version vector, for
+// a client joining mid-session to catch up without replaying history.
+func (d *Document) Snapshot() ([]snapshotItem, map[string]uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	items := make([]snapshotItem, 0, len(d.index))
+	for cur := d.head.next; cur != nil; cur = cur.next {
+		items = append(items, snapshotItem{ID: cur.id, Parent: cur.parent, Char: cur.char, Tombstone: cur.tombstone})
+	}
+	return items, d.versionVectorLocked()
+}
+
+// VersionVector returns a copy of the highest clock integrated per site so
+// far.
+func (d *Document) VersionVector() map[string]uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.versionVectorLocked()
+}
+
+func (d *Document) versionVectorLocked() map[string]uint64 {
+	version := make(map[string]uint64, len(d.version))
+	for site, clock := range d.version {
+		version[site] = clock
+	}
+	return version
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+// TestDocumentSequentialInserts verifies that chaining each op's Parent to
+// the previous op's server-assigned OpID reconstructs the typed order.
+func TestDocumentSequentialInserts(t *testing.T) {
+	doc := newDocument()
+
+	var parent OpID
+	for i, ch := range "abc" {
+		op := doc.Integrate(Op{
+			OpID:   OpID{SiteID: "site-1", Clock: uint64(i)},
+			Parent: parent,
+			Action: actionInsert,
+			Char:   string(ch),
+		})
+		parent = op.OpID
+	}
+
+	if text := doc.Text(); text != "abc" {
+		t.Errorf("Text() = %q, want %q", text, "abc")
+	}
+}
+
+// TestDocumentConcurrentInsertsAreDeterministic verifies that two inserts
+// sharing the same parent (concurrent siblings) are ordered the same way
+// every time the same arrival order is replayed, since every client's
+// document is built from the single order the Hub integrates ops in.
+func TestDocumentConcurrentInsertsAreDeterministic(t *testing.T) {
+	build := func() string {
+		doc := newDocument()
+		doc.Integrate(Op{OpID: OpID{SiteID: "site-a", Clock: 1}, Action: actionInsert, Char: "a"})
+		doc.Integrate(Op{OpID: OpID{SiteID: "site-b", Clock: 1}, Action: actionInsert, Char: "b"})
+		return doc.Text()
+	}
+
+	first := build()
+	second := build()
+	if first != second {
+		t.Errorf("replaying the same arrival order diverged: %q vs %q", first, second)
+	}
+}
+
+// TestDocumentDelete verifies a delete op tombstones the target character
+// instead of physically removing it, so later ops can still reference it.
+func TestDocumentDelete(t *testing.T) {
+	doc := newDocument()
+
+	inserted := doc.Integrate(Op{OpID: OpID{SiteID: "site-1", Clock: 1}, Action: actionInsert, Char: "x"})
+	doc.Integrate(Op{OpID: OpID{SiteID: "site-1", Clock: 2}, Parent: inserted.OpID, Action: actionDelete})
+
+	if text := doc.Text(); text != "" {
+		t.Errorf("Text() = %q, want empty string after delete", text)
+	}
+}
+
+// TestDocumentSnapshotVersionVector verifies Snapshot reports the highest
+// clock integrated per site, for a client catching up mid-session.
+func TestDocumentSnapshotVersionVector(t *testing.T) {
+	doc := newDocument()
+	doc.Integrate(Op{OpID: OpID{SiteID: "site-1", Clock: 1}, Action: actionInsert, Char: "a"})
+	doc.Integrate(Op{OpID: OpID{SiteID: "site-2", Clock: 1}, Action: actionInsert, Char: "b"})
+
+	items, version := doc.Snapshot()
+	if len(items) != 2 {
+		t.Fatalf("Snapshot() returned %d items, want 2", len(items))
+	}
+	if len(version) != 2 {
+		t.Errorf("version vector has %d entries, want 2", len(version))
+	}
+}
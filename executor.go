@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// compileTimeout bounds how long any single executor may spend compiling or
+// running candidate-submitted code.
+const compileTimeout = 10 * time.Second
+
+// Executor compiles and/or runs code in a single language. Compile is used
+// for languages that produce a standalone artifact (e.g. Go's wasm binary);
+// Run is used for languages that are interpreted in place.
+type Executor interface {
+	// Compile builds code and returns the resulting artifact bytes along
+	// with its content type and whether it was served from the result
+	// cache rather than freshly built. Executors that have no separate
+	// compile step should return an error.
+	Compile(ctx context.Context, code string) (artifact []byte, contentType string, cached bool, err error)
+	// Run executes code directly and returns its combined stdout/stderr.
+	Run(ctx context.Context, code string) (output string, err error)
+}
+
+// executors holds the registered backend for each supported language.
+var executors = map[string]Executor{
+	"go":         goWasmExecutor{},
+	"javascript": jsExecutor{},
+	"python":     pythonExecutor{},
+}
+
+func executorFor(language string) (Executor, error) {
+	if language == "" {
+		language = "go"
+	}
+	ex, ok := executors[language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q", language)
+	}
+	return ex, nil
+}
+
+// goWasmExecutor compiles Go source to a GOOS=js/GOARCH=wasm binary,
+// checking out a warm worker from compilerPool and consulting compileCache
+// so identical submissions are served without rebuilding.
+type goWasmExecutor struct{}
+
+func (goWasmExecutor) Compile(ctx context.Context, code string) ([]byte, string, bool, error) {
+	key := cacheKey("go", code)
+	if artifact, contentType, ok := compileCache.get(key); ok {
+		compileCacheHits.Add(1)
+		return artifact, contentType, true, nil
+	}
+	compileCacheMisses.Add(1)
+
+	worker, err := compilerPool.checkout(ctx)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer compilerPool.checkin(worker)
+
+	if err := os.WriteFile(filepath.Join(worker.dir, "main.go"), []byte(code), 0644); err != nil {
+		return nil, "", false, fmt.Errorf("write code: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", "main.wasm", "main.go")
+	cmd.Dir = worker.dir
+	cmd.Env = compilerPool.buildEnv()
+	if err := applySandbox(cmd); err != nil {
+		return nil, "", false, err
+	}
+
+	start := time.Now()
+	output, buildErr := cmd.CombinedOutput()
+	recordBuildDuration(time.Since(start))
+	if buildErr != nil {
+		return nil, "", false, &compileError{output: output}
+	}
+
+	wasmBytes, err := os.ReadFile(filepath.Join(worker.dir, "main.wasm"))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("read wasm: %w", err)
+	}
+
+	compileCache.put(key, "application/wasm", wasmBytes)
+	return wasmBytes, "application/wasm", false, nil
+}
+
+func (goWasmExecutor) Run(ctx context.Context, code string) (string, error) {
+	return "", fmt.Errorf("go: running wasm requires a browser runtime, use Compile")
+}
+
+// compileError carries raw compiler output for a failed build so handlers
+// can report it as a 400 rather than a 500.
+type compileError struct {
+	output []byte
+}
+
+func (e *compileError) Error() string { return string(e.output) }
+
+// jsExecutor runs JavaScript in a Node subprocess.
+type jsExecutor struct{}
+
+func (jsExecutor) Compile(ctx context.Context, code string) ([]byte, string, bool, error) {
+	return nil, "", false, fmt.Errorf("javascript: no compile step, use Run")
+}
+
+func (jsExecutor) Run(ctx context.Context, code string) (string, error) {
+	return runInterpreter(ctx, "node", []string{"-e", code})
+}
+
+// pythonExecutor runs Python via a python3 subprocess.
+type pythonExecutor struct{}
+
+func (pythonExecutor) Compile(ctx context.Context, code string) ([]byte, string, bool, error) {
+	return nil, "", false, fmt.Errorf("python: no compile step, use Run")
+}
+
+func (pythonExecutor) Run(ctx context.Context, code string) (string, error) {
+	return runInterpreter(ctx, "python3", []string{"-c", code})
+}
+
+// runInterpreter executes an interpreter binary with the given args under
+// the shared compile timeout and sandbox limits, returning combined output.
+func runInterpreter(ctx context.Context, bin string, args []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, compileTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	if err := applySandbox(cmd); err != nil {
+		return "", err
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return string(output), fmt.Errorf("%s: timed out after %s", bin, compileTimeout)
+		}
+		return string(output), fmt.Errorf("%s: %w", bin, err)
+	}
+	return string(output), nil
+}
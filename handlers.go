@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// compileRequest is the body accepted by /compile.
+type compileRequest struct {
+	Code      string `json:"code"`
+	Language  string `json:"language"`
+	SessionID string `json:"sessionId"`
+	Token     string `json:"token"`
+}
+
+// compileResponse is the structured result returned by /compile. Artifact
+// holds a data URL for languages that produce a binary (e.g. Go's wasm
+// output); Stdout/Stderr are populated for interpreted languages.
+type compileResponse struct {
+	Stdout      string `json:"stdout"`
+	Stderr      string `json:"stderr"`
+	ExitCode    int    `json:"exitCode"`
+	DurationMs  int64  `json:"durationMs"`
+	ArtifactURL string `json:"artifactURL,omitempty"`
+}
+
+// handleRoot serves the SPA shell for "/" and "/interview/{id}", and
+// dispatches "/interview/{id}/history" and "/interview/{id}/replay" to
+// their dedicated handlers.
+func handleRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		http.ServeFile(w, r, "./static/index.html")
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/interview/") {
+		rest := strings.TrimPrefix(r.URL.Path, "/interview/")
+		if parts := strings.SplitN(rest, "/", 2); len(parts) == 2 {
+			switch parts[1] {
+			case "history":
+				handleSessionHistory(w, r, parts[0])
+				return
+			case "replay":
+				handleSessionReplay(w, r, parts[0])
+				return
+			}
+		}
+		http.ServeFile(w, r, "./static/index.html")
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// authorizeInterviewer checks the "token" query parameter for a valid,
+// unexpired token scoped to sessionID with the interviewer role, writing a
+// 401 and returning false if it doesn't pass.
+func authorizeInterviewer(w http.ResponseWriter, r *http.Request, sessionID string) bool {
+	claims, err := parseToken(r.URL.Query().Get("token"))
+	if err != nil || claims.SessionID != sessionID || claims.Role != RoleInterviewer {
+		http.Error(w, "Invalid or mismatched session token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleSessionHistory returns the ordered event log recorded for a
+// session as JSON, so an interviewer can review what happened afterward.
+func handleSessionHistory(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !authorizeInterviewer(w, r, sessionID) {
+		return
+	}
+
+	events, err := hubManager.store.Events(sessionID)
+	if err != nil {
+		http.Error(w, "Failed to load session history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		log.Printf("Error encoding history response: %v", err)
+	}
+}
+
+// handleSessionReplay streams a session's recorded events back over a
+// websocket with their original inter-event timing, scaled by the
+// "speed" query parameter (default 1x; 2 plays twice as fast), so an
+// interviewer can review a past session as it happened.
+func handleSessionReplay(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if !authorizeInterviewer(w, r, sessionID) {
+		return
+	}
+
+	speed := 1.0
+	if raw := r.URL.Query().Get("speed"); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed > 0 {
+			speed = parsed
+		}
+	}
+
+	events, err := hubManager.store.Events(sessionID)
+	if err != nil {
+		http.Error(w, "Failed to load session history", http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("replay upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var previous time.Time
+	for i, event := range events {
+		if i > 0 {
+			if delay := event.Timestamp.Sub(previous); delay > 0 {
+				time.Sleep(time.Duration(float64(delay) / speed))
+			}
+		}
+		previous = event.Timestamp
+		if err := conn.WriteMessage(websocket.TextMessage, event.Payload); err != nil {
+			return
+		}
+	}
+}
+
+// handleCreate starts a new interview session and redirects the caller to
+// it, handing back a signed, time-boxed token for each role via query
+// parameters so the client can route the interviewer and candidate to
+// views with different permissions.
+func handleCreate(w http.ResponseWriter, r *http.Request) {
+	id := generateID()
+
+	interviewerToken, err := issueToken(id, RoleInterviewer)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	candidateToken, err := issueToken(id, RoleCandidate)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	target := url.URL{Path: "/interview/" + id}
+	query := target.Query()
+	query.Set("interviewerToken", interviewerToken)
+	query.Set("candidateToken", candidateToken)
+	target.RawQuery = query.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// handleCompile dispatches a compile/run request to the Executor registered
+// for the requested language and reports the result as JSON. If the request
+// names a live session, the result is also broadcast over that session's
+// hub so every participant sees the run.
+func handleCompile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req compileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	executor, err := executorFor(req.Language)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Every /compile call must carry a valid token, whether or not it names
+	// a live session; if it does name one, the token must be scoped to that
+	// same session, and the hub's authoritative document is compiled rather
+	// than trusting whatever the requesting client last had on screen.
+	claims, err := parseToken(req.Token)
+	if err != nil || (req.SessionID != "" && claims.SessionID != req.SessionID) {
+		http.Error(w, "Invalid or mismatched session token", http.StatusUnauthorized)
+		return
+	}
+
+	code := req.Code
+	var hub *Hub
+	hasHub := false
+	if req.SessionID != "" {
+		hub, hasHub = hubManager.lookup(req.SessionID)
+		if hasHub {
+			code = hub.GetText()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), compileTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp := compileResponse{ExitCode: 0}
+
+	artifact, contentType, cached, err := executor.Compile(ctx, code)
+	switch {
+	case err == nil:
+		resp.ArtifactURL = artifactDataURL(contentType, artifact)
+		if cached {
+			w.Header().Set("X-Cache", "HIT")
+		} else {
+			w.Header().Set("X-Cache", "MISS")
+		}
+	case errors.Is(err, ErrPoolSaturated):
+		http.Error(w, "Compiler pool is saturated, try again shortly", http.StatusServiceUnavailable)
+		return
+	default:
+		if ce, ok := err.(*compileError); ok {
+			resp.Stderr = ce.Error()
+			resp.ExitCode = 1
+		} else {
+			// Language has no compile step (e.g. javascript/python); run
+			// it directly instead.
+			output, runErr := executor.Run(ctx, code)
+			resp.Stdout = output
+			if runErr != nil {
+				resp.Stderr = runErr.Error()
+				resp.ExitCode = 1
+			}
+		}
+	}
+	resp.DurationMs = time.Since(start).Milliseconds()
+
+	if hasHub {
+		hub.recordLanguage(req.Language)
+		if payload, err := json.Marshal(map[string]any{"type": "run_result", "result": resp}); err == nil {
+			hub.Broadcast(payload)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.ExitCode != 0 {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding compile response: %v", err)
+	}
+}
+
+// artifactDataURL encodes a compiled artifact as a data URL so callers can
+// fetch/instantiate it without a separate storage round trip.
+func artifactDataURL(contentType string, artifact []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(artifact)
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/ku-ba/vibe/store"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// Client is a single websocket connection participating in a Hub, scoped
+// to the Role its session token granted it.
+type Client struct {
+	hub  *Hub
+	id   string
+	role Role
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// inboundMessage pairs a raw client message with the Client that sent it,
+// so the Hub can enforce role-scoped permissions before acting on it.
+type inboundMessage struct {
+	client  *Client
+	payload []byte
+}
+
+// Hub owns the CRDT Document for one interview session and keeps every
+// connected client's view of it converged: inbound ops are integrated into
+// the document and the finalized result is broadcast to all clients,
+// including the one that sent it. If store is non-nil every inbound
+// message is also appended to the session's event log so the session can
+// survive a restart and be replayed later.
+type Hub struct {
+	clients    map[*Client]bool
+	document   *Document
+	sessionID  string
+	store      store.SessionStore
+	broadcast  chan []byte
+	inbound    chan inboundMessage
+	register   chan *Client
+	unregister chan *Client
+}
+
+func newHub(sessionID string, st store.SessionStore) *Hub {
+	return &Hub{
+		clients:    make(map[*Client]bool),
+		document:   newDocument(),
+		sessionID:  sessionID,
+		store:      st,
+		broadcast:  make(chan []byte),
+		inbound:    make(chan inboundMessage),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+	}
+}
+
+// Broadcast queues a message for delivery to every connected client.
+func (h *Hub) Broadcast(message []byte) {
+	h.broadcast <- message
+}
+
+// GetText returns the authoritative document contents, used by /compile so
+// the server compiles what was actually integrated rather than whatever
+// the requesting client last had on screen.
+func (h *Hub) GetText() string {
+	return h.document.Text()
+}
+
+func (h *Hub) run() {
+	for {
+		select {
+		case client := <-h.register:
+			h.clients[client] = true
+			h.recordParticipant(client.id)
+			h.sendSnapshot(client)
+			h.broadcastPresence(client, "joined")
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				delete(h.clients, client)
+				close(client.send)
+				h.broadcastPresence(client, "left")
+			}
+		case message := <-h.inbound:
+			h.handleInbound(message)
+		case message := <-h.broadcast:
+			h.fanOut(message)
+		}
+	}
+}
+
+// messageRoles restricts each inbound message type to the roles allowed to
+// send it: only the interviewer drives execution and ends the session; only
+// the candidate edits the document.
+var messageRoles = map[string]Role{
+	"op":          RoleCandidate,
+	"run_code":    RoleInterviewer,
+	"end_session": RoleInterviewer,
+}
+
+// handleInbound, once the sender's role is allowed to send that message
+// type, records the message to the session's event log and then integrates
+// ops into the document and rebroadcasts the finalized op, or rebroadcasts
+// run_code/end_session as-is. Everything else (unrecognized types, or a
+// type the sender's role isn't scoped to send) is dropped rather than
+// rebroadcast or persisted, since every participant's view - live or
+// replayed after a restart - must come from role-valid history, not from
+// relayed bytes.
+func (h *Hub) handleInbound(msg inboundMessage) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(msg.payload, &envelope); err != nil {
+		log.Printf("hub: dropping unparseable message: %v", err)
+		return
+	}
+
+	allowedRole, recognized := messageRoles[envelope.Type]
+	if !recognized {
+		log.Printf("hub: dropping message with unknown type %q", envelope.Type)
+		return
+	}
+	if msg.client.role != allowedRole {
+		log.Printf("hub: dropping %q from role %q, requires %q", envelope.Type, msg.client.role, allowedRole)
+		return
+	}
+
+	if h.store != nil {
+		if err := h.store.Append(h.sessionID, msg.payload); err != nil {
+			log.Printf("hub: append event for session %q: %v", h.sessionID, err)
+		}
+	}
+
+	switch envelope.Type {
+	case "op":
+		var op Op
+		if err := json.Unmarshal(msg.payload, &op); err != nil {
+			log.Printf("hub: dropping malformed op: %v", err)
+			return
+		}
+		finalized := h.document.Integrate(op)
+		payload, err := json.Marshal(map[string]any{
+			"type":    "op",
+			"opId":    finalized.OpID,
+			"parent":  finalized.Parent,
+			"action":  finalized.Action,
+			"pos":     finalized.Pos,
+			"char":    finalized.Char,
+			"version": h.document.VersionVector(),
+		})
+		if err != nil {
+			log.Printf("hub: marshal integrated op: %v", err)
+			return
+		}
+		h.fanOut(payload)
+	case "run_code", "end_session":
+		h.fanOut(msg.payload)
+	}
+}
+
+// sendSnapshot delivers the current document state and version vector to a
+// newly registered client so it can catch up without replaying history.
+func (h *Hub) sendSnapshot(client *Client) {
+	items, version := h.document.Snapshot()
+	payload, err := json.Marshal(map[string]any{
+		"type":    "snapshot",
+		"items":   items,
+		"version": version,
+	})
+	if err != nil {
+		log.Printf("hub: marshal snapshot: %v", err)
+		return
+	}
+	select {
+	case client.send <- payload:
+	default:
+	}
+}
+
+// replay re-integrates a previously recorded event log into the document
+// without touching the store or broadcasting, rebuilding state before any
+// client connects.
+func (h *Hub) replay(events []store.Event) {
+	for _, event := range events {
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(event.Payload, &envelope); err != nil || envelope.Type != "op" {
+			continue
+		}
+		var op Op
+		if err := json.Unmarshal(event.Payload, &op); err != nil {
+			continue
+		}
+		h.document.Integrate(op)
+	}
+}
+
+// recordParticipant appends clientID to the session's participant list in
+// its stored metadata.
+func (h *Hub) recordParticipant(clientID string) {
+	if h.store == nil {
+		return
+	}
+	meta, err := h.store.Metadata(h.sessionID)
+	if err != nil {
+		log.Printf("hub: load metadata for session %q: %v", h.sessionID, err)
+		return
+	}
+	meta.SessionID = h.sessionID
+	meta.Participants = append(meta.Participants, clientID)
+	if err := h.store.SetMetadata(h.sessionID, meta); err != nil {
+		log.Printf("hub: update metadata for session %q: %v", h.sessionID, err)
+	}
+}
+
+// recordLanguage stamps the session's metadata with the language last used
+// to compile it.
+func (h *Hub) recordLanguage(language string) {
+	if h.store == nil || language == "" {
+		return
+	}
+	meta, err := h.store.Metadata(h.sessionID)
+	if err != nil {
+		log.Printf("hub: load metadata for session %q: %v", h.sessionID, err)
+		return
+	}
+	meta.SessionID = h.sessionID
+	meta.Language = language
+	if err := h.store.SetMetadata(h.sessionID, meta); err != nil {
+		log.Printf("hub: update metadata for session %q: %v", h.sessionID, err)
+	}
+}
+
+// broadcastPresence tells every connected client that client's role just
+// joined or left.
+func (h *Hub) broadcastPresence(client *Client, status string) {
+	payload, err := json.Marshal(map[string]any{
+		"type":     "presence",
+		"clientId": client.id,
+		"role":     client.role,
+		"status":   status,
+	})
+	if err != nil {
+		log.Printf("hub: marshal presence: %v", err)
+		return
+	}
+	h.fanOut(payload)
+}
+
+// fanOut delivers message to every connected client, dropping clients
+// whose send buffer is full rather than blocking the hub.
+func (h *Hub) fanOut(message []byte) {
+	for client := range h.clients {
+		select {
+		case client.send <- message:
+		default:
+			close(client.send)
+			delete(h.clients, client)
+		}
+	}
+}
+
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister <- c
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("websocket read error: %v", err)
+			}
+			break
+		}
+		c.hub.inbound <- inboundMessage{client: c, payload: message}
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// HubManager manages the active hubs, one per interview session. If store
+// is non-nil, sessions persist across restarts: getOrCreateHub replays a
+// session's event log the first time it's reopened.
+type HubManager struct {
+	hubs  map[string]*Hub
+	store store.SessionStore
+	mu    sync.RWMutex
+}
+
+func newHubManager(st store.SessionStore) *HubManager {
+	return &HubManager{
+		hubs:  make(map[string]*Hub),
+		store: st,
+	}
+}
+
+// getOrCreateHub returns the hub for id, starting one if this is the first
+// client to reach that session. If a store is configured and a log already
+// exists for id (e.g. from before a restart), its events are replayed into
+// the new hub's document before any client connects.
+func (hm *HubManager) getOrCreateHub(id string) *Hub {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	if hub, ok := hm.hubs[id]; ok {
+		return hub
+	}
+
+	hub := newHub(id, hm.store)
+	if hm.store != nil {
+		exists, err := hm.store.Exists(id)
+		if err != nil {
+			log.Printf("hub: check session store for %q: %v", id, err)
+		}
+		if exists {
+			events, err := hm.store.Events(id)
+			if err != nil {
+				log.Printf("hub: load events for %q: %v", id, err)
+			} else {
+				hub.replay(events)
+			}
+		} else if err := hm.store.SetMetadata(id, store.Metadata{SessionID: id, CreatedAt: time.Now()}); err != nil {
+			log.Printf("hub: init metadata for %q: %v", id, err)
+		}
+	}
+
+	go hub.run()
+	hm.hubs[id] = hub
+	return hub
+}
+
+// lookup returns the hub for id without creating one, for callers (like
+// /compile) that should only broadcast to sessions that already exist.
+func (hm *HubManager) lookup(id string) (*Hub, bool) {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+	hub, ok := hm.hubs[id]
+	return hub, ok
+}
+
+// serveWs validates the "token" query parameter against hub's session
+// before upgrading the HTTP connection to a websocket and registering a
+// new Client with the role the token was issued for.
+func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	claims, err := parseToken(r.URL.Query().Get("token"))
+	if err != nil || claims.SessionID != hub.sessionID {
+		http.Error(w, "Invalid or mismatched session token", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("upgrade error: %v", err)
+		return
+	}
+
+	client := &Client{hub: hub, id: generateID(), role: claims.Role, conn: conn, send: make(chan []byte, 256)}
+	client.hub.register <- client
+
+	go client.writePump()
+	go client.readPump()
+}
@@ -107,7 +107,11 @@ func TestCompileHandler(t *testing.T) {
 	func main() {
 		fmt.Println("Hello, World!")
 	}`
-	body, _ := json.Marshal(map[string]string{"code": code})
+	token, err := issueToken("", RoleCandidate)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	body, _ := json.Marshal(map[string]string{"code": code, "token": token})
 	req, err = http.NewRequest("POST", "/compile", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatal(err)
@@ -127,16 +131,50 @@ func TestCompileHandler(t *testing.T) {
 		t.Errorf("handler returned wrong status code for valid code: got %v want %v",
 			status, http.StatusOK)
 	}
-	
-	if contentType := rr.Header().Get("Content-Type"); contentType != "application/wasm" {
-		t.Errorf("handler returned wrong content type: got %v want application/wasm", contentType)
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("handler returned wrong content type: got %v want application/json", contentType)
+	}
+
+	var result compileResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode compile response: %v", err)
+	}
+	if !strings.HasPrefix(result.ArtifactURL, "data:application/wasm;base64,") {
+		t.Errorf("handler returned wrong artifact URL: got %v", result.ArtifactURL)
 	}
 }
 
+// readMessageOfType reads from ws until it sees a message whose "type"
+// field equals want, discarding anything else (e.g. presence broadcasts
+// interleaved with the messages a test actually cares about) so tests
+// don't have to hardcode how many incidental messages precede the one
+// they're asserting on.
+func readMessageOfType(t *testing.T, ws *websocket.Conn, want string) []byte {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		_, payload, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("read message: %v", err)
+		}
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(payload, &envelope); err != nil {
+			t.Fatalf("unmarshal message: %v", err)
+		}
+		if envelope.Type == want {
+			return payload
+		}
+	}
+	t.Fatalf("did not see a %q message", want)
+	return nil
+}
+
 // TestWebSocketHandler tests the /ws/{id} endpoint
 func TestWebSocketHandler(t *testing.T) {
 	// Setup HubManager
-	hubManager := newHubManager()
+	hubManager := newHubManager(nil)
 
 	// Create a test server with the WebSocket handler logic
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -153,8 +191,13 @@ func TestWebSocketHandler(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Convert http URL to ws URL
-	u := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/test-session"
+	// A candidate token scoped to this session is required to connect and
+	// to send edits.
+	token, err := issueToken("test-session", RoleCandidate)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	u := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/test-session?token=" + token
 
 	// Connect to the WebSocket
 	ws, _, err := websocket.DefaultDialer.Dial(u, nil)
@@ -163,31 +206,39 @@ func TestWebSocketHandler(t *testing.T) {
 	}
 	defer ws.Close()
 
-	// Send a message
-	message := []byte("hello world")
-	if err := ws.WriteMessage(websocket.TextMessage, message); err != nil {
-		t.Fatalf("write: %v", err)
-	}
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
 
-	// Set read deadline to prevent hanging if no message is received
-	ws.SetReadDeadline(time.Now().Add(time.Second * 5))
+	// Joining sends a snapshot of the (empty) document, alongside a
+	// presence message announcing this client joined.
+	readMessageOfType(t, ws, "snapshot")
 
-	// Read the message back (should be broadcasted to all clients, including sender)
-	_, p, err := ws.ReadMessage()
+	// Send an insert op.
+	op := Op{Type: "op", OpID: OpID{SiteID: "site-1", Clock: 1}, Action: actionInsert, Char: "h"}
+	opBytes, err := json.Marshal(op)
 	if err != nil {
-		t.Fatalf("read: %v", err)
+		t.Fatal(err)
+	}
+	if err := ws.WriteMessage(websocket.TextMessage, opBytes); err != nil {
+		t.Fatalf("write: %v", err)
 	}
 
-	if !bytes.Equal(message, p) {
-		t.Errorf("echo: got %s, want %s", p, message)
+	// The integrated op is broadcast back, including to the sender, after
+	// the still-unread presence message from this client's own join.
+	p := readMessageOfType(t, ws, "op")
+	var got map[string]any
+	if err := json.Unmarshal(p, &got); err != nil {
+		t.Fatalf("unmarshal op: %v", err)
+	}
+	if got["type"] != "op" || got["char"] != "h" {
+		t.Errorf("unexpected integrated op: %s", p)
 	}
 }
 
-
 // TestMultiClientJSExecution tests the full flow: create session, connect clients, sync code, execute JS
 func TestMultiClientJSExecution(t *testing.T) {
-	// 1. Setup Server
-	hubManager := newHubManager()
+	// 1. Setup Server. Uses the package-level hubManager (same instance
+	// handleCompile resolves sessions against) rather than a throwaway
+	// one, so /compile sees the document built up over the websocket.
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasPrefix(r.URL.Path, "/ws/") {
 			parts := strings.Split(r.URL.Path, "/")
@@ -216,69 +267,92 @@ func TestMultiClientJSExecution(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create session: %v", err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		// handleCreate redirects, so client follows it. 
-		// But wait, handleCreate redirects to /interview/{id}. 
-		// Our test server doesn't handle /interview/{id} specifically in the mux above, 
-		// but http.Get follows redirects.
-		// Let's check the final URL to get the ID.
-	}
-	
-	// Extract session ID from the URL
-	// The URL will be something like http://127.0.0.1:port/interview/abcd
+	// Extract session ID and per-role tokens from the redirect.
+	// The URL will be something like
+	// http://127.0.0.1:port/interview/abcd?interviewerToken=...&candidateToken=...
 	parts := strings.Split(resp.Request.URL.Path, "/")
 	sessionID := parts[len(parts)-1]
 	if sessionID == "" {
 		t.Fatalf("Failed to extract session ID from URL: %s", resp.Request.URL.Path)
 	}
+	candidateToken := resp.Request.URL.Query().Get("candidateToken")
+	interviewerToken := resp.Request.URL.Query().Get("interviewerToken")
+	if candidateToken == "" || interviewerToken == "" {
+		t.Fatalf("Failed to extract session tokens from URL: %s", resp.Request.URL)
+	}
 
-	// 3. Connect Client 1
+	// 3. Connect Client 1 as the candidate, since it's the one typing code.
 	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID
-	ws1, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	ws1, _, err := websocket.DefaultDialer.Dial(wsURL+"?token="+candidateToken, nil)
 	if err != nil {
 		t.Fatalf("Client 1 failed to connect: %v", err)
 	}
 	defer ws1.Close()
 
-	// 4. Connect Client 2
-	ws2, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	// 4. Connect Client 2 as the interviewer, observing.
+	ws2, _, err := websocket.DefaultDialer.Dial(wsURL+"?token="+interviewerToken, nil)
 	if err != nil {
 		t.Fatalf("Client 2 failed to connect: %v", err)
 	}
 	defer ws2.Close()
 
-	// 5. Client 1 sends valid JavaScript code
-	jsCode := `console.log("Hello JS Integration Test")`
-	msg := map[string]string{
-		"type":    "code_update",
-		"content": jsCode,
-	}
-	msgBytes, _ := json.Marshal(msg)
-	if err := ws1.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
-		t.Fatalf("Client 1 failed to send message: %v", err)
-	}
-
-	// 6. Verify Client 2 receives the code update
+	// Both clients receive a snapshot of the (empty) document on join,
+	// alongside presence messages announcing each client's arrival.
+	ws1.SetReadDeadline(time.Now().Add(5 * time.Second))
+	readMessageOfType(t, ws1, "snapshot")
 	ws2.SetReadDeadline(time.Now().Add(5 * time.Second))
-	_, p, err := ws2.ReadMessage()
-	if err != nil {
-		t.Fatalf("Client 2 failed to read message: %v", err)
-	}
+	readMessageOfType(t, ws2, "snapshot")
+
+	// 5. Client 1 types valid JavaScript code one character at a time, each
+	// insert op chained off the previous one's server-assigned OpID so the
+	// document converges on the typed order.
+	jsCode := `console.log(1+1)`
+	var parent OpID
+	for i, ch := range jsCode {
+		op := Op{
+			Type:   "op",
+			OpID:   OpID{SiteID: "client1", Clock: uint64(i + 1)},
+			Parent: parent,
+			Action: actionInsert,
+			Pos:    i,
+			Char:   string(ch),
+		}
+		opBytes, err := json.Marshal(op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ws1.WriteMessage(websocket.TextMessage, opBytes); err != nil {
+			t.Fatalf("Client 1 failed to send op %d: %v", i, err)
+		}
 
-	var receivedMsg map[string]string
-	if err := json.Unmarshal(p, &receivedMsg); err != nil {
-		t.Fatalf("Failed to unmarshal received message: %v", err)
-	}
+		// Client 1 reads its own integrated op back (skipping any
+		// still-pending presence messages) to learn the server-assigned
+		// OpID to chain the next character onto.
+		p := readMessageOfType(t, ws1, "op")
+		var integrated Op
+		if err := json.Unmarshal(p, &integrated); err != nil {
+			t.Fatalf("Client 1 failed to unmarshal integrated op %d: %v", i, err)
+		}
+		parent = integrated.OpID
 
-	if receivedMsg["content"] != jsCode {
-		t.Errorf("Client 2 received wrong code: got %q, want %q", receivedMsg["content"], jsCode)
+		// 6. Verify Client 2 receives the same op.
+		p2 := readMessageOfType(t, ws2, "op")
+		var fromClient2 Op
+		if err := json.Unmarshal(p2, &fromClient2); err != nil {
+			t.Fatalf("Failed to unmarshal op %d received by Client 2: %v", i, err)
+		}
+		if fromClient2.Char != string(ch) {
+			t.Errorf("Client 2 received wrong char at %d: got %q, want %q", i, fromClient2.Char, string(ch))
+		}
 	}
 
-	// 7. Compile/Execute the code
-	// We use the same server URL for compile endpoint
+	// 7. Compile/Execute the document. A token scoped to the session is
+	// required; /compile fetches the authoritative text from the hub
+	// rather than trusting a client-submitted code field.
 	compileReq := map[string]string{
-		"code":     jsCode,
-		"language": "javascript",
+		"sessionId": sessionID,
+		"language":  "javascript",
+		"token":     interviewerToken,
 	}
 	compileBody, _ := json.Marshal(compileReq)
 	resp, err = http.Post(server.URL+"/compile", "application/json", bytes.NewBuffer(compileBody))
@@ -292,17 +366,18 @@ func TestMultiClientJSExecution(t *testing.T) {
 	}
 
 	// 8. Verify execution result
-	// For JS, we expect text/plain output
+	// The response body is always JSON, regardless of language.
 	contentType := resp.Header.Get("Content-Type")
-	if contentType != "text/plain" {
-		t.Errorf("Wrong Content-Type: got %v, want text/plain", contentType)
+	if contentType != "application/json" {
+		t.Errorf("Wrong Content-Type: got %v, want application/json", contentType)
 	}
 
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
-	output := buf.String()
+	var result compileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode compile response: %v", err)
+	}
 
-	if !strings.Contains(output, "Hello JS Integration Test") {
-		t.Errorf("Execution output wrong: got %q, want it to contain 'Hello JS Integration Test'", output)
+	if !strings.Contains(result.Stdout, "2") {
+		t.Errorf("Execution output wrong: got %q, want it to contain %q", result.Stdout, "2")
 	}
 }
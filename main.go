@@ -1,43 +1,81 @@
 package main
 
-
 import (
 	"crypto/rand"
-	"encoding/json"
 	"encoding/hex"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
-	"sync"
+
+	"github.com/ku-ba/vibe/store"
 )
 
-// HubManager manages active hubs for different interview sessions
-type HubManager struct {
-	hubs map[string]*Hub
-	mu   sync.RWMutex
-}
+// sessionStoreDir holds every session's append-only event log and
+// metadata, so interviews survive a server restart.
+const sessionStoreDir = "./data/sessions"
+
+// sessionStoreSQLitePath is where the session store's database lives when
+// SESSION_STORE_DRIVER selects sqlite.
+const sessionStoreSQLitePath = "./data/sessions.db"
+
+// compilerPoolDir holds every warm Go build worker's scratch directory
+// plus the shared GOCACHE/GOMODCACHE they all build against.
+const compilerPoolDir = "./data/compiler-pool"
+
+// compileCacheResultDir holds compiled artifacts cached by content hash so
+// repeat submissions are served without rebuilding.
+const compileCacheResultDir = "./data/compile-cache"
+
+// hubManager holds every live interview session's Hub.
+var hubManager = newHubManager(mustSessionStore())
 
-func newHubManager() *HubManager {
-	return &HubManager{
-		hubs: make(map[string]*Hub),
+// compilerPool hands out warm Go build workers for goWasmExecutor.
+var compilerPool = mustCompilerPool()
+
+// compileCache caches compiled artifacts by content hash.
+var compileCache = mustCompileCache()
+
+// mustSessionStore opens the session store selected by SESSION_STORE_DRIVER
+// ("file", the default, or "sqlite"), failing fast if it can't be opened.
+func mustSessionStore() store.SessionStore {
+	switch driver := os.Getenv("SESSION_STORE_DRIVER"); driver {
+	case "", "file":
+		st, err := store.NewFileStore(sessionStoreDir)
+		if err != nil {
+			log.Fatalf("create session store: %v", err)
+		}
+		return st
+	case "sqlite":
+		st, err := store.NewSQLiteStore(sessionStoreSQLitePath)
+		if err != nil {
+			log.Fatalf("create session store: %v", err)
+		}
+		return st
+	default:
+		log.Fatalf("create session store: unknown SESSION_STORE_DRIVER %q (want \"file\" or \"sqlite\")", driver)
+		return nil
 	}
 }
 
-func (hm *HubManager) getOrCreateHub(id string) *Hub {
-	hm.mu.Lock()
-	defer hm.mu.Unlock()
-
-	if hub, ok := hm.hubs[id]; ok {
-		return hub
+// mustCompilerPool starts the warm Go build worker pool, failing fast if
+// its scratch directories can't be created.
+func mustCompilerPool() *CompilerPool {
+	pool, err := newCompilerPool(compilerPoolSize, compilerPoolDir, compilerCheckoutTimeout)
+	if err != nil {
+		log.Fatalf("create compiler pool: %v", err)
 	}
+	return pool
+}
 
-	hub := newHub()
-	go hub.run()
-	hm.hubs[id] = hub
-	return hub
+// mustCompileCache opens the on-disk compile result cache, failing fast if
+// its directory can't be created.
+func mustCompileCache() *ResultCache {
+	cache, err := newResultCache(compileCacheResultDir, compileCacheMaxBytes)
+	if err != nil {
+		log.Fatalf("create compile cache: %v", err)
+	}
+	return cache
 }
 
 func generateID() string {
@@ -49,31 +87,13 @@ func generateID() string {
 }
 
 func main() {
-	hubManager := newHubManager()
-
 	// Serve static files from the "static" directory
 	fs := http.FileServer(http.Dir("./static"))
 	http.Handle("/static/", http.StripPrefix("/static/", fs))
 
-	// Root handler - serve index.html
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" {
-			http.ServeFile(w, r, "./static/index.html")
-			return
-		}
-		// Handle /interview/{id}
-		if strings.HasPrefix(r.URL.Path, "/interview/") {
-			http.ServeFile(w, r, "./static/index.html")
-			return
-		}
-		http.NotFound(w, r)
-	})
-
-	// Create new interview session
-	http.HandleFunc("/create", func(w http.ResponseWriter, r *http.Request) {
-		id := generateID()
-		http.Redirect(w, r, "/interview/"+id, http.StatusFound)
-	})
+	http.HandleFunc("/", handleRoot)
+	http.HandleFunc("/create", handleCreate)
+	http.HandleFunc("/compile", handleCompile)
 
 	// WebSocket handler
 	http.HandleFunc("/ws/", func(w http.ResponseWriter, r *http.Request) {
@@ -84,68 +104,11 @@ func main() {
 			return
 		}
 		id := parts[2]
-		
+
 		hub := hubManager.getOrCreateHub(id)
 		serveWs(hub, w, r)
 	})
 
-	http.HandleFunc("/compile", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Parse JSON body
-		var req struct {
-			Code string `json:"code"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			return
-		}
-
-		// Create temp dir
-		tmpDir, err := os.MkdirTemp("", "wasm-build-*")
-		if err != nil {
-			http.Error(w, "Failed to create temp dir", http.StatusInternalServerError)
-			log.Printf("Error creating temp dir: %v", err)
-			return
-		}
-		defer os.RemoveAll(tmpDir)
-
-		// Write code to main.go
-		if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(req.Code), 0644); err != nil {
-			http.Error(w, "Failed to write code", http.StatusInternalServerError)
-			log.Printf("Error writing code: %v", err)
-			return
-		}
-
-		// Run go build
-		cmd := exec.Command("go", "build", "-o", "main.wasm", "main.go")
-		cmd.Dir = tmpDir
-		cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			// Compilation error
-			w.Header().Set("Content-Type", "text/plain")
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(output)
-			return
-		}
-
-		// Read wasm file
-		wasmBytes, err := os.ReadFile(filepath.Join(tmpDir, "main.wasm"))
-		if err != nil {
-			http.Error(w, "Failed to read wasm", http.StatusInternalServerError)
-			log.Printf("Error reading wasm: %v", err)
-			return
-		}
-
-		// Send wasm
-		w.Header().Set("Content-Type", "application/wasm")
-		w.Write(wasmBytes)
-	})
-
 	log.Println("Server started on :8080")
 	err := http.ListenAndServe(":8080", nil)
 	if err != nil {
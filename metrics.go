@@ -0,0 +1,34 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// Published under /debug/vars (registered by the expvar package's own
+// init) so /compile's cache effectiveness and build latency can be
+// observed without standing up a separate metrics stack.
+var (
+	compileCacheHits        = expvar.NewInt("compile_cache_hits")
+	compileCacheMisses      = expvar.NewInt("compile_cache_misses")
+	compilerQueueRejections = expvar.NewInt("compiler_queue_rejections")
+	compileBuildDurationMs  = expvar.NewMap("compile_build_duration_ms_histogram")
+)
+
+// buildDurationBucketsMs are the upper bounds, in milliseconds, of the
+// compile_build_duration_ms_histogram buckets.
+var buildDurationBucketsMs = []int64{100, 500, 1000, 2500, 5000}
+
+// recordBuildDuration files d into the published build-duration histogram,
+// rolling anything past the last bucket into an overflow bucket.
+func recordBuildDuration(d time.Duration) {
+	ms := d.Milliseconds()
+	for _, bucket := range buildDurationBucketsMs {
+		if ms <= bucket {
+			compileBuildDurationMs.Add(fmt.Sprintf("<=%dms", bucket), 1)
+			return
+		}
+	}
+	compileBuildDurationMs.Add("5000ms+", 1)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+const (
+	// sandboxMemoryLimitBytes bounds RLIMIT_AS. It has to stay well above
+	// what any submission will actually resident-use: both the Go
+	// toolchain and V8 (node) reserve large virtual address ranges up
+	// front (e.g. Go's heap arenas, V8's CodeRange) regardless of how
+	// little memory the program underneath actually touches, and an
+	// RLIMIT_AS below ~1GB makes them fail to start at all rather than
+	// bounding a runaway submission.
+	sandboxMemoryLimitBytes = 1 << 30 // 1GB
+	sandboxCPUSeconds       = 10
+	sandboxFileSizeBytes    = 32 << 20 // 32MB
+)
+
+// applySandbox wraps cmd so the child process (never the server itself) is
+// capped on memory, CPU time, and output file size, preferring nsjail's
+// network isolation when available and otherwise falling back to prlimit.
+// It errors rather than running code unsandboxed if neither is on PATH.
+func applySandbox(cmd *exec.Cmd) error {
+	if nsjail, err := exec.LookPath("nsjail"); err == nil {
+		wrapWithNsjail(cmd, nsjail)
+		return nil
+	}
+	if prlimit, err := exec.LookPath("prlimit"); err == nil {
+		wrapWithPrlimit(cmd, prlimit)
+		return nil
+	}
+	return fmt.Errorf("sandbox: neither nsjail nor prlimit found on PATH, refusing to run unsandboxed")
+}
+
+// wrapWithNsjail re-points cmd at the nsjail binary, running the original
+// command and args inside a network-isolated jail with matching rlimits.
+func wrapWithNsjail(cmd *exec.Cmd, nsjailPath string) {
+	original := append([]string{cmd.Path}, cmd.Args[1:]...)
+	args := []string{
+		"--mode", "o",
+		"--disable_clone_newnet=false",
+		"--rlimit_as", strconv.Itoa(sandboxMemoryLimitBytes / (1 << 20)),
+		"--rlimit_cpu", strconv.Itoa(sandboxCPUSeconds),
+		"--rlimit_fsize", strconv.Itoa(sandboxFileSizeBytes / (1 << 20)),
+		"--cwd", cmd.Dir,
+		"--",
+	}
+	args = append(args, original...)
+
+	cmd.Path = nsjailPath
+	cmd.Args = append([]string{nsjailPath}, args...)
+}
+
+// wrapWithPrlimit re-points cmd at the prlimit binary, which applies
+// RLIMIT_AS/RLIMIT_CPU/RLIMIT_FSIZE to the process it execs in place of
+// itself — unlike syscall.Setrlimit, which (with no fork/exec hook
+// available in exec.Cmd) would instead permanently lower the calling
+// server process's own limits.
+func wrapWithPrlimit(cmd *exec.Cmd, prlimitPath string) {
+	original := append([]string{cmd.Path}, cmd.Args[1:]...)
+	args := []string{
+		fmt.Sprintf("--as=%d", sandboxMemoryLimitBytes),
+		fmt.Sprintf("--cpu=%d", sandboxCPUSeconds),
+		fmt.Sprintf("--fsize=%d", sandboxFileSizeBytes),
+		"--",
+	}
+	args = append(args, original...)
+
+	cmd.Path = prlimitPath
+	cmd.Args = append([]string{prlimitPath}, args...)
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore persists each session as a pair of files under dir: an
+// append-only "<id>.jsonl" event log and an "<id>.meta.json" metadata
+// file. It's the zero-dependency default; SQLiteStore is the better fit
+// once a deployment needs concurrent access from multiple processes.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+
+	// seqs caches the next sequence number to assign per session, so
+	// Append doesn't have to re-read and re-decode the whole event log on
+	// every call just to count the lines already in it. It's hydrated
+	// lazily from disk the first time a session is appended to.
+	seqs map[string]int64
+}
+
+// NewFileStore creates dir (if needed) and returns a FileStore rooted
+// there.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create session store dir: %w", err)
+	}
+	return &FileStore{dir: dir, seqs: make(map[string]int64)}, nil
+}
+
+func (s *FileStore) eventsPath(sessionID string) string {
+	return filepath.Join(s.dir, sessionID+".jsonl")
+}
+
+func (s *FileStore) metaPath(sessionID string) string {
+	return filepath.Join(s.dir, sessionID+".meta.json")
+}
+
+// Exists reports whether an event log file exists for sessionID.
+func (s *FileStore) Exists(sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := os.Stat(s.eventsPath(sessionID))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Append appends payload as the next line of sessionID's event log.
+func (s *FileStore) Append(sessionID string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seq, err := s.nextSeqLocked(sessionID)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.eventsPath(sessionID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open event log: %w", err)
+	}
+	defer f.Close()
+
+	event := Event{Seq: seq, Timestamp: time.Now(), Payload: payload}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+
+	s.seqs[sessionID] = seq + 1
+	return nil
+}
+
+// nextSeqLocked returns the sequence number the next appended event for
+// sessionID should use. The first call for a session falls back to
+// counting the events already on disk; every call after that is an O(1)
+// map lookup instead of re-reading the whole log. s.mu must be held.
+func (s *FileStore) nextSeqLocked(sessionID string) (int64, error) {
+	if seq, ok := s.seqs[sessionID]; ok {
+		return seq, nil
+	}
+	events, err := s.readEventsLocked(sessionID)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(events)) + 1, nil
+}
+
+// Events returns every recorded event for sessionID, oldest first, or nil
+// if the session has no log yet.
+func (s *FileStore) Events(sessionID string) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readEventsLocked(sessionID)
+}
+
+func (s *FileStore) readEventsLocked(sessionID string) ([]Event, error) {
+	f, err := os.Open(s.eventsPath(sessionID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open event log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("decode event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan event log: %w", err)
+	}
+	return events, nil
+}
+
+// Metadata returns sessionID's metadata, or a fresh record stamped with
+// the current time if none has been stored yet.
+func (s *FileStore) Metadata(sessionID string) (Metadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.metaPath(sessionID))
+	if os.IsNotExist(err) {
+		return Metadata{SessionID: sessionID, CreatedAt: time.Now()}, nil
+	}
+	if err != nil {
+		return Metadata{}, fmt.Errorf("read metadata: %w", err)
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Metadata{}, fmt.Errorf("decode metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// SetMetadata replaces sessionID's stored metadata.
+func (s *FileStore) SetMetadata(sessionID string, meta Metadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+	if err := os.WriteFile(s.metaPath(sessionID), data, 0644); err != nil {
+		return fmt.Errorf("write metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore persists sessions in a single SQLite database: an
+// append-only events table keyed by (session_id, seq), and a sessions
+// table holding metadata. Preferred over FileStore once more than one
+// server process needs to read/write the same sessions.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) the SQLite database at path
+// and ensures its schema exists.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create sqlite database dir: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+
+	const schema = `
+CREATE TABLE IF NOT EXISTS events (
+	session_id TEXT NOT NULL,
+	seq        INTEGER NOT NULL,
+	timestamp  TEXT NOT NULL,
+	payload    BLOB NOT NULL,
+	PRIMARY KEY (session_id, seq)
+);
+CREATE TABLE IF NOT EXISTS sessions (
+	session_id   TEXT PRIMARY KEY,
+	created_at   TEXT NOT NULL,
+	participants TEXT NOT NULL,
+	language     TEXT NOT NULL
+);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close releases the underlying database handle.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Exists reports whether any events have been recorded for sessionID.
+func (s *SQLiteStore) Exists(sessionID string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM events WHERE session_id = ?`, sessionID).Scan(&n)
+	return n > 0, err
+}
+
+// Append records payload as the next event for sessionID.
+func (s *SQLiteStore) Append(sessionID string, payload []byte) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM events WHERE session_id = ?`, sessionID).Scan(&seq); err != nil {
+		return fmt.Errorf("read last seq: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO events (session_id, seq, timestamp, payload) VALUES (?, ?, ?, ?)`,
+		sessionID, seq+1, time.Now().Format(time.RFC3339Nano), payload); err != nil {
+		return fmt.Errorf("insert event: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Events returns every recorded event for sessionID, oldest first.
+func (s *SQLiteStore) Events(sessionID string) ([]Event, error) {
+	rows, err := s.db.Query(`SELECT seq, timestamp, payload FROM events WHERE session_id = ? ORDER BY seq ASC`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var (
+			event Event
+			ts    string
+		)
+		if err := rows.Scan(&event.Seq, &ts, &event.Payload); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		if event.Timestamp, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+			return nil, fmt.Errorf("parse event timestamp: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Metadata returns sessionID's metadata, or a fresh record stamped with
+// the current time if none has been stored yet.
+func (s *SQLiteStore) Metadata(sessionID string) (Metadata, error) {
+	var (
+		meta         = Metadata{SessionID: sessionID}
+		createdAt    string
+		participants string
+	)
+	row := s.db.QueryRow(`SELECT created_at, participants, language FROM sessions WHERE session_id = ?`, sessionID)
+	switch err := row.Scan(&createdAt, &participants, &meta.Language); err {
+	case sql.ErrNoRows:
+		meta.CreatedAt = time.Now()
+		return meta, nil
+	case nil:
+		t, err := time.Parse(time.RFC3339Nano, createdAt)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("parse created_at: %w", err)
+		}
+		meta.CreatedAt = t
+		if err := json.Unmarshal([]byte(participants), &meta.Participants); err != nil {
+			return Metadata{}, fmt.Errorf("decode participants: %w", err)
+		}
+		return meta, nil
+	default:
+		return Metadata{}, fmt.Errorf("query metadata: %w", err)
+	}
+}
+
+// SetMetadata replaces sessionID's stored metadata.
+func (s *SQLiteStore) SetMetadata(sessionID string, meta Metadata) error {
+	participants, err := json.Marshal(meta.Participants)
+	if err != nil {
+		return fmt.Errorf("marshal participants: %w", err)
+	}
+	_, err = s.db.Exec(`
+INSERT INTO sessions (session_id, created_at, participants, language)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(session_id) DO UPDATE SET participants = excluded.participants, language = excluded.language`,
+		sessionID, meta.CreatedAt.Format(time.RFC3339Nano), string(participants), meta.Language)
+	if err != nil {
+		return fmt.Errorf("upsert metadata: %w", err)
+	}
+	return nil
+}
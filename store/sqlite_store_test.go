@@ -0,0 +1,67 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStoreAppendAndEvents(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer s.Close()
+
+	if exists, err := s.Exists("sess-1"); err != nil || exists {
+		t.Fatalf("Exists() = %v, %v; want false, nil", exists, err)
+	}
+
+	if err := s.Append("sess-1", []byte(`{"type":"op"}`)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := s.Append("sess-1", []byte(`{"type":"op"}`)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if exists, err := s.Exists("sess-1"); err != nil || !exists {
+		t.Fatalf("Exists() = %v, %v; want true, nil", exists, err)
+	}
+
+	events, err := s.Events("sess-1")
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("len(Events()) = %d, want 2", len(events))
+	}
+	if events[0].Seq != 1 || events[1].Seq != 2 {
+		t.Errorf("unexpected sequence numbers: %d, %d", events[0].Seq, events[1].Seq)
+	}
+}
+
+func TestSQLiteStoreMetadataRoundTrip(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer s.Close()
+
+	meta, err := s.Metadata("sess-1")
+	if err != nil {
+		t.Fatalf("Metadata: %v", err)
+	}
+	meta.Participants = append(meta.Participants, "client-1")
+	meta.Language = "javascript"
+
+	if err := s.SetMetadata("sess-1", meta); err != nil {
+		t.Fatalf("SetMetadata: %v", err)
+	}
+
+	got, err := s.Metadata("sess-1")
+	if err != nil {
+		t.Fatalf("Metadata: %v", err)
+	}
+	if got.Language != "javascript" || len(got.Participants) != 1 || got.Participants[0] != "client-1" {
+		t.Errorf("Metadata() = %+v, want language=javascript, participants=[client-1]", got)
+	}
+}
@@ -0,0 +1,37 @@
+// Package store persists interview sessions as append-only event logs so
+// they survive a server restart and can be replayed after the fact.
+package store
+
+import "time"
+
+// Event is a single inbound message recorded for a session, in arrival
+// order.
+type Event struct {
+	Seq       int64     `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	Payload   []byte    `json:"payload"`
+}
+
+// Metadata describes a session for bookkeeping and the history endpoints.
+type Metadata struct {
+	SessionID    string    `json:"sessionId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Participants []string  `json:"participants"`
+	Language     string    `json:"language"`
+}
+
+// SessionStore persists a session's inbound messages as an append-only
+// event log plus its metadata.
+type SessionStore interface {
+	// Exists reports whether any events have been recorded for sessionID.
+	Exists(sessionID string) (bool, error)
+	// Append records payload as the next event for sessionID.
+	Append(sessionID string, payload []byte) error
+	// Events returns every recorded event for sessionID, oldest first.
+	Events(sessionID string) ([]Event, error)
+	// Metadata returns sessionID's metadata, or a fresh zero-value record
+	// (stamped with the current time) if none has been stored yet.
+	Metadata(sessionID string) (Metadata, error)
+	// SetMetadata replaces sessionID's stored metadata.
+	SetMetadata(sessionID string, meta Metadata) error
+}